@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAwsSecretsManagerSecretVersion() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAwsSecretsManagerSecretVersionRead,
+
+		Schema: map[string]*schema.Schema{
+			"arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"secret_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"version_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"version_stage"},
+			},
+			"version_stage": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"version_id"},
+			},
+			"secret_string": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"secret_binary": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"version_stages": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceAwsSecretsManagerSecretVersionRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).secretsmanagerconn
+	secretID := d.Get("secret_id").(string)
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	}
+
+	if v, ok := d.GetOk("version_id"); ok {
+		input.VersionId = aws.String(v.(string))
+	}
+
+	if v, ok := d.GetOk("version_stage"); ok {
+		input.VersionStage = aws.String(v.(string))
+	}
+
+	output, err := conn.GetSecretValue(input)
+	if err != nil {
+		return fmt.Errorf("error reading Secrets Manager Secret Version: %w", err)
+	}
+
+	if output == nil {
+		return fmt.Errorf("error reading Secrets Manager Secret Version: empty response")
+	}
+
+	d.SetId(fmt.Sprintf("%s|%s", secretID, aws.StringValue(output.VersionId)))
+	d.Set("arn", output.ARN)
+	d.Set("secret_id", secretID)
+	d.Set("secret_string", output.SecretString)
+	d.Set("secret_binary", base64Encode(output.SecretBinary))
+	d.Set("version_id", output.VersionId)
+
+	if err := d.Set("version_stages", flattenStringList(output.VersionStages)); err != nil {
+		return fmt.Errorf("error setting version_stages: %w", err)
+	}
+
+	return nil
+}
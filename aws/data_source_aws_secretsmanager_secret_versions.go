@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAwsSecretsManagerSecretVersions() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAwsSecretsManagerSecretVersionsRead,
+
+		Schema: map[string]*schema.Schema{
+			"secret_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"include_deprecated": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"versions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"version_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"version_stages": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"last_accessed_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"created_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAwsSecretsManagerSecretVersionsRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).secretsmanagerconn
+	secretID := d.Get("secret_id").(string)
+
+	versions, err := listSecretsManagerSecretVersions(conn, secretID, d.Get("include_deprecated").(bool))
+	if err != nil {
+		return fmt.Errorf("error listing Secrets Manager Secret (%s) Versions: %w", secretID, err)
+	}
+
+	sortSecretsManagerSecretVersionsByAge(versions)
+
+	d.SetId(secretID)
+	d.Set("secret_id", secretID)
+
+	if err := d.Set("versions", flattenSecretsManagerSecretVersionsListEntries(versions)); err != nil {
+		return fmt.Errorf("error setting versions: %w", err)
+	}
+
+	return nil
+}
+
+// listSecretsManagerSecretVersions returns every version of secretID, paginating
+// through ListSecretVersionIds. When includeDeprecated is true, versions with no
+// staging labels attached (i.e. deprecated versions) are included in the result.
+func listSecretsManagerSecretVersions(conn *secretsmanager.SecretsManager, secretID string, includeDeprecated bool) ([]*secretsmanager.SecretVersionsListEntry, error) {
+	input := &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(secretID),
+		IncludeDeprecated: aws.Bool(includeDeprecated),
+	}
+
+	var versions []*secretsmanager.SecretVersionsListEntry
+
+	err := conn.ListSecretVersionIdsPages(input, func(page *secretsmanager.ListSecretVersionIdsOutput, lastPage bool) bool {
+		versions = append(versions, page.Versions...)
+		return !lastPage
+	})
+
+	return versions, err
+}
+
+// sortSecretsManagerSecretVersionsByAge sorts versions newest-first, using
+// LastAccessedDate when available and falling back to CreatedDate otherwise.
+func sortSecretsManagerSecretVersionsByAge(versions []*secretsmanager.SecretVersionsListEntry) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return secretsManagerSecretVersionAge(versions[i]).After(secretsManagerSecretVersionAge(versions[j]))
+	})
+}
+
+func secretsManagerSecretVersionAge(version *secretsmanager.SecretVersionsListEntry) time.Time {
+	if version.LastAccessedDate != nil {
+		return aws.TimeValue(version.LastAccessedDate)
+	}
+	return aws.TimeValue(version.CreatedDate)
+}
+
+func flattenSecretsManagerSecretVersionsListEntries(versions []*secretsmanager.SecretVersionsListEntry) []interface{} {
+	result := make([]interface{}, 0, len(versions))
+
+	for _, version := range versions {
+		v := map[string]interface{}{
+			"version_id":     aws.StringValue(version.VersionId),
+			"version_stages": flattenStringList(version.VersionStages),
+		}
+
+		if version.LastAccessedDate != nil {
+			v["last_accessed_date"] = aws.TimeValue(version.LastAccessedDate).Format(time.RFC3339)
+		}
+
+		if version.CreatedDate != nil {
+			v["created_date"] = aws.TimeValue(version.CreatedDate).Format(time.RFC3339)
+		}
+
+		result = append(result, v)
+	}
+
+	return result
+}
@@ -1,8 +1,12 @@
 package aws
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"strings"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/hashicorp/aws-sdk-go-base/tfawserr"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/terraform-providers/terraform-provider-aws/aws/internal/service/secretsmanager/waiter"
 	"github.com/terraform-providers/terraform-provider-aws/aws/internal/tfresource"
 )
@@ -24,6 +29,7 @@ func resourceAwsSecretsManagerSecretVersion() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
+		CustomizeDiff: resourceAwsSecretsManagerSecretVersionCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"arn": {
@@ -40,14 +46,35 @@ func resourceAwsSecretsManagerSecretVersion() *schema.Resource {
 				Optional:      true,
 				ForceNew:      true,
 				Sensitive:     true,
-				ConflictsWith: []string{"secret_binary"},
+				ConflictsWith: []string{"secret_binary", "secret_binary_source"},
 			},
 			"secret_binary": {
 				Type:          schema.TypeString,
 				Optional:      true,
+				Computed:      true,
 				ForceNew:      true,
 				Sensitive:     true,
-				ConflictsWith: []string{"secret_string"},
+				ConflictsWith: []string{"secret_string", "secret_binary_source"},
+			},
+			"secret_binary_raw": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"secret_binary_source"},
+			},
+			"secret_binary_source": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"secret_string", "secret_binary", "secret_binary_raw"},
+			},
+			"secret_binary_size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"secret_binary_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
 			},
 			"version_id": {
 				Type:     schema.TypeString,
@@ -59,10 +86,50 @@ func resourceAwsSecretsManagerSecretVersion() *schema.Resource {
 				Computed: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
+			"version_stage": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"max_outdated_versions": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
 		},
 	}
 }
 
+// resourceAwsSecretsManagerSecretVersionCustomizeDiff forces a new version
+// when secret_binary_source is set and the contents of the file it points at
+// have changed since the last apply. Only the file path is tracked as a
+// config input, so Terraform cannot otherwise detect drift in the file's
+// contents; this hashes the local file at plan time and compares it against
+// the digest recorded from the last read of the remote secret.
+func resourceAwsSecretsManagerSecretVersionCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	path, ok := d.GetOk("secret_binary_source")
+	if !ok {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path.(string))
+	if err != nil {
+		return fmt.Errorf("error reading secret_binary_source (%s): %w", path.(string), err)
+	}
+
+	sum := sha256.Sum256(data)
+	localDigest := hex.EncodeToString(sum[:])
+
+	if localDigest != d.Get("secret_binary_sha256").(string) {
+		if err := d.SetNew("secret_binary_sha256", localDigest); err != nil {
+			return err
+		}
+		return d.ForceNew("secret_binary_sha256")
+	}
+
+	return nil
+}
+
 func resourceAwsSecretsManagerSecretVersionCreate(d *schema.ResourceData, meta interface{}) error {
 	conn := meta.(*AWSClient).secretsmanagerconn
 	secretID := d.Get("secret_id").(string)
@@ -75,18 +142,31 @@ func resourceAwsSecretsManagerSecretVersionCreate(d *schema.ResourceData, meta i
 		input.SecretString = aws.String(v.(string))
 	}
 
-	if v, ok := d.GetOk("secret_binary"); ok {
-		vs := []byte(v.(string))
+	if v, ok := d.GetOk("secret_binary_source"); ok {
+		path := v.(string)
 
-		if !isBase64Encoded(vs) {
-			return fmt.Errorf("expected base64 in secret_binary")
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading secret_binary_source (%s): %w", path, err)
 		}
 
-		var err error
-		input.SecretBinary, err = base64.StdEncoding.DecodeString(v.(string))
+		input.SecretBinary = data
+	} else if v, ok := d.GetOk("secret_binary"); ok {
+		vs := v.(string)
 
-		if err != nil {
-			return fmt.Errorf("error decoding secret binary value: %s", err)
+		if d.Get("secret_binary_raw").(bool) {
+			input.SecretBinary = []byte(vs)
+		} else {
+			if !isBase64Encoded([]byte(vs)) {
+				return fmt.Errorf("expected base64 in secret_binary")
+			}
+
+			var err error
+			input.SecretBinary, err = base64.StdEncoding.DecodeString(vs)
+
+			if err != nil {
+				return fmt.Errorf("error decoding secret binary value: %s", err)
+			}
 		}
 	}
 
@@ -94,28 +174,97 @@ func resourceAwsSecretsManagerSecretVersionCreate(d *schema.ResourceData, meta i
 		input.VersionStages = expandStringSet(v.(*schema.Set))
 	}
 
+	versionStage := d.Get("version_stage").(string)
+	if versionStage != "" && !sliceContainsString(input.VersionStages, versionStage) {
+		input.VersionStages = append(input.VersionStages, aws.String(versionStage))
+	}
+
 	log.Printf("[DEBUG] Putting Secrets Manager Secret %q value", secretID)
 	output, err := conn.PutSecretValue(input)
 	if err != nil {
 		return fmt.Errorf("error putting Secrets Manager Secret value: %s", err)
 	}
 
-	d.SetId(fmt.Sprintf("%s|%s", secretID, aws.StringValue(output.VersionId)))
+	if versionStage != "" {
+		d.SetId(fmt.Sprintf("%s|%s|%s", secretID, aws.StringValue(output.VersionId), versionStage))
+	} else {
+		d.SetId(fmt.Sprintf("%s|%s", secretID, aws.StringValue(output.VersionId)))
+	}
+
+	if v, ok := d.GetOk("max_outdated_versions"); ok {
+		if err := pruneSecretsManagerSecretOutdatedVersions(conn, secretID, v.(int)); err != nil {
+			return fmt.Errorf("error pruning Secrets Manager Secret (%s) outdated versions: %w", secretID, err)
+		}
+	}
 
 	return resourceAwsSecretsManagerSecretVersionRead(d, meta)
 }
 
+// pruneSecretsManagerSecretOutdatedVersions retains at most maxOutdated
+// non-current versions of secretID, keeping the most recently
+// accessed/created ones. Secrets Manager only garbage collects versions that
+// have no staging labels at all, so versions beyond the retention window are
+// pruned by stripping any labels they still carry (e.g. AWSPREVIOUS or a
+// custom stage) rather than by a direct delete call, which the API does not
+// expose for a single version.
+func pruneSecretsManagerSecretOutdatedVersions(conn *secretsmanager.SecretsManager, secretID string, maxOutdated int) error {
+	versions, err := listSecretsManagerSecretVersions(conn, secretID, true)
+	if err != nil {
+		return err
+	}
+
+	var prunable []*secretsmanager.SecretVersionsListEntry
+	for _, version := range versions {
+		if sliceContainsString(version.VersionStages, "AWSCURRENT") {
+			continue
+		}
+		prunable = append(prunable, version)
+	}
+
+	if len(prunable) <= maxOutdated {
+		return nil
+	}
+
+	sortSecretsManagerSecretVersionsByAge(prunable)
+
+	for _, version := range prunable[maxOutdated:] {
+		for _, stage := range version.VersionStages {
+			input := &secretsmanager.UpdateSecretVersionStageInput{
+				RemoveFromVersionId: version.VersionId,
+				SecretId:            aws.String(secretID),
+				VersionStage:        stage,
+			}
+
+			log.Printf(
+				"[DEBUG] Stripping Secrets Manager Secret %q version %q staging label %q to allow pruning past max_outdated_versions (%d)",
+				secretID, aws.StringValue(version.VersionId), aws.StringValue(stage), maxOutdated,
+			)
+
+			if _, err := conn.UpdateSecretVersionStage(input); err != nil {
+				return fmt.Errorf("error stripping staging label %q from version %q: %w", aws.StringValue(stage), aws.StringValue(version.VersionId), err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func resourceAwsSecretsManagerSecretVersionRead(d *schema.ResourceData, meta interface{}) error {
 	conn := meta.(*AWSClient).secretsmanagerconn
 
-	secretID, versionID, err := decodeSecretsManagerSecretVersionID(d.Id())
+	secretID, versionID, versionStage, err := decodeSecretsManagerSecretVersionID(d.Id())
 	if err != nil {
 		return err
 	}
 
 	input := &secretsmanager.GetSecretValueInput{
-		SecretId:  aws.String(secretID),
-		VersionId: aws.String(versionID),
+		SecretId: aws.String(secretID),
+	}
+
+	if versionStage != "" {
+		input.VersionStage = aws.String(versionStage)
+	} else {
+		input.VersionId = aws.String(versionID)
 	}
 
 	var output *secretsmanager.GetSecretValueOutput
@@ -166,10 +315,25 @@ func resourceAwsSecretsManagerSecretVersionRead(d *schema.ResourceData, meta int
 
 	d.Set("secret_id", secretID)
 	d.Set("secret_string", output.SecretString)
-	d.Set("secret_binary", base64Encode(output.SecretBinary))
+
+	if d.Get("secret_binary_raw").(bool) {
+		d.Set("secret_binary", string(output.SecretBinary))
+	} else {
+		d.Set("secret_binary", base64Encode(output.SecretBinary))
+	}
+
+	d.Set("secret_binary_size", len(output.SecretBinary))
 	d.Set("version_id", output.VersionId)
+	d.Set("version_stage", versionStage)
 	d.Set("arn", output.ARN)
 
+	if len(output.SecretBinary) > 0 {
+		sum := sha256.Sum256(output.SecretBinary)
+		d.Set("secret_binary_sha256", hex.EncodeToString(sum[:]))
+	} else {
+		d.Set("secret_binary_sha256", "")
+	}
+
 	if err := d.Set("version_stages", flattenStringList(output.VersionStages)); err != nil {
 		return fmt.Errorf("error setting version_stages: %s", err)
 	}
@@ -180,7 +344,7 @@ func resourceAwsSecretsManagerSecretVersionRead(d *schema.ResourceData, meta int
 func resourceAwsSecretsManagerSecretVersionUpdate(d *schema.ResourceData, meta interface{}) error {
 	conn := meta.(*AWSClient).secretsmanagerconn
 
-	secretID, versionID, err := decodeSecretsManagerSecretVersionID(d.Id())
+	secretID, versionID, _, err := decodeSecretsManagerSecretVersionID(d.Id())
 	if err != nil {
 		return err
 	}
@@ -191,94 +355,52 @@ func resourceAwsSecretsManagerSecretVersionUpdate(d *schema.ResourceData, meta i
 	stagesToAdd := ns.Difference(os).List()
 	stagesToRemove := os.Difference(ns).List()
 
-	var describedSecret *secretsmanager.DescribeSecretOutput
-	awsPreviousVersionID := aws.String(versionID)
-
-	for _, stage := range stagesToAdd {
-		input := &secretsmanager.UpdateSecretVersionStageInput{
-			MoveToVersionId: aws.String(versionID),
-			SecretId:        aws.String(secretID),
-			VersionStage:    aws.String(stage.(string)),
+	if len(stagesToAdd) > 0 || len(stagesToRemove) > 0 {
+		describedSecret, err := conn.DescribeSecret(&secretsmanager.DescribeSecretInput{SecretId: aws.String(secretID)})
+		if err != nil {
+			return fmt.Errorf("error describing Secrets Manager Secret %q: %w", secretID, err)
 		}
 
-		if stage.(string) == "AWSCURRENT" {
-			log.Printf("[DEBUG] Going to set AWSCURRENT staging label for secret %q version %q", secretID, versionID)
-
-			// NOTE: Cache it to prevent calling it more than once
-			if describedSecret == nil {
-				describedSecret, err = conn.DescribeSecret(&secretsmanager.DescribeSecretInput{SecretId: aws.String(secretID)})
-				if err != nil {
-					return fmt.Errorf("error updating Secrets Manager Secret %q Version Stage %q: %s", secretID, stage.(string), err)
-				}
+		for _, stage := range stagesToAdd {
+			stage := stage.(string)
 
-				var awsCurrentStageVersionID *string
-
-				var nextToken *string = nil
-
-			loopListVersionIDsPagination:
-				for {
-					output, err := conn.ListSecretVersionIds(&secretsmanager.ListSecretVersionIdsInput{
-						NextToken: nextToken,
-						SecretId:  aws.String(secretID),
-					})
-					if err != nil {
-						return fmt.Errorf("error updating Secrets Manager Secret %q Version Stage %q: %s", secretID, stage.(string), err)
-					}
-
-					for _, version := range output.Versions {
-						for _, versionStage := range version.VersionStages {
-							// NOTE: Even though AWS API can return multiple version stages to a single version,
-							// there's only one `AWSCURRENT`, therefore return early.
-							if versionStage != nil && *versionStage == "AWSCURRENT" {
-								awsCurrentStageVersionID = version.VersionId
-								break loopListVersionIDsPagination
-							}
-						}
-					}
-
-					if output.NextToken == nil {
-						break
-					}
-
-					nextToken = output.NextToken
-				}
+			input := &secretsmanager.UpdateSecretVersionStageInput{
+				MoveToVersionId: aws.String(versionID),
+				SecretId:        aws.String(secretID),
+				VersionStage:    aws.String(stage),
+			}
 
-				input.RemoveFromVersionId = awsCurrentStageVersionID
+			if sourceVersionID := secretsManagerSecretVersionIDForStage(describedSecret.VersionIdsToStages, stage); sourceVersionID != nil && aws.StringValue(sourceVersionID) != versionID {
+				input.RemoveFromVersionId = sourceVersionID
 				log.Printf(
-					"[DEBUG] Going to move AWSCURRENT staging label for secret %q from version: %q to version %q",
-					secretID,
-					*awsCurrentStageVersionID,
-					versionID,
+					"[DEBUG] Going to move %q staging label for secret %q from version %q to version %q",
+					stage, secretID, aws.StringValue(sourceVersionID), versionID,
 				)
 			}
 
-		}
-		log.Printf("[DEBUG] Updating Secrets Manager Secret Version Stage: %s", input)
-		_, err := conn.UpdateSecretVersionStage(input)
-		if err != nil {
-			return fmt.Errorf("error updating Secrets Manager Secret %q Version Stage %q: %s", secretID, stage.(string), err)
+			if err := updateSecretsManagerSecretVersionStageWithRetry(conn, input); err != nil {
+				return fmt.Errorf("error updating Secrets Manager Secret %q Version Stage %q: %w", secretID, stage, err)
+			}
 		}
 
-		// NOTE: After changing the `AWSCURRENT`, the previous `AWSCURRENT` is now `AWSPREVIOUS`,
-		// which we'll need to remove previous labels.
-		awsPreviousVersionID = input.RemoveFromVersionId
-	}
+		for _, stage := range stagesToRemove {
+			stage := stage.(string)
 
-	for _, stage := range stagesToRemove {
-		// InvalidParameterException: You can only move staging label AWSCURRENT to a different secret version. It can’t be completely removed.
-		if stage.(string) == "AWSCURRENT" {
-			log.Printf("[INFO] Skipping removal of AWSCURRENT staging label for secret %q version %q", secretID, versionID)
-			continue
-		}
-		input := &secretsmanager.UpdateSecretVersionStageInput{
-			RemoveFromVersionId: awsPreviousVersionID,
-			SecretId:            aws.String(secretID),
-			VersionStage:        aws.String(stage.(string)),
-		}
-		log.Printf("[DEBUG] Updating Secrets Manager Secret Version Stage: %s", input)
-		_, err := conn.UpdateSecretVersionStage(input)
-		if err != nil {
-			return fmt.Errorf("error updating Secrets Manager Secret %q Version Stage %q: %s", secretID, stage.(string), err)
+			// InvalidParameterException: You can only move staging label AWSCURRENT to a different secret version. It can’t be completely removed.
+			if stage == "AWSCURRENT" {
+				log.Printf("[INFO] Skipping removal of AWSCURRENT staging label for secret %q version %q", secretID, versionID)
+				continue
+			}
+
+			input := &secretsmanager.UpdateSecretVersionStageInput{
+				RemoveFromVersionId: aws.String(versionID),
+				SecretId:            aws.String(secretID),
+				VersionStage:        aws.String(stage),
+			}
+
+			if err := updateSecretsManagerSecretVersionStageWithRetry(conn, input); err != nil {
+				return fmt.Errorf("error updating Secrets Manager Secret %q Version Stage %q: %w", secretID, stage, err)
+			}
 		}
 	}
 
@@ -288,7 +410,7 @@ func resourceAwsSecretsManagerSecretVersionUpdate(d *schema.ResourceData, meta i
 func resourceAwsSecretsManagerSecretVersionDelete(d *schema.ResourceData, meta interface{}) error {
 	conn := meta.(*AWSClient).secretsmanagerconn
 
-	secretID, versionID, err := decodeSecretsManagerSecretVersionID(d.Id())
+	secretID, versionID, _, err := decodeSecretsManagerSecretVersionID(d.Id())
 	if err != nil {
 		return err
 	}
@@ -322,10 +444,71 @@ func resourceAwsSecretsManagerSecretVersionDelete(d *schema.ResourceData, meta i
 	return nil
 }
 
-func decodeSecretsManagerSecretVersionID(id string) (string, string, error) {
-	idParts := strings.Split(id, "|")
-	if len(idParts) != 2 {
-		return "", "", fmt.Errorf("expected ID in format SecretID|VersionID, received: %s", id)
+// decodeSecretsManagerSecretVersionID parses a resource ID in either the
+// legacy SecretID|VersionID form or the SecretID|VersionID|VersionStage form
+// used when the resource is configured with version_stage. The returned
+// stage is empty when the ID does not encode one.
+func decodeSecretsManagerSecretVersionID(id string) (string, string, string, error) {
+	idParts := strings.SplitN(id, "|", 3)
+	if len(idParts) < 2 || len(idParts) > 3 {
+		return "", "", "", fmt.Errorf("expected ID in format SecretID|VersionID or SecretID|VersionID|VersionStage, received: %s", id)
+	}
+
+	var stage string
+	if len(idParts) == 3 {
+		stage = idParts[2]
+	}
+
+	return idParts[0], idParts[1], stage, nil
+}
+
+// secretsManagerSecretVersionIDForStage returns the version ID that currently
+// holds stage, per the VersionIdsToStages map returned by DescribeSecret, or
+// nil if no version is currently labeled with it.
+func secretsManagerSecretVersionIDForStage(versionIdsToStages map[string][]*string, stage string) *string {
+	for versionID, stages := range versionIdsToStages {
+		for _, s := range stages {
+			if aws.StringValue(s) == stage {
+				return aws.String(versionID)
+			}
+		}
+	}
+	return nil
+}
+
+// updateSecretsManagerSecretVersionStageWithRetry calls UpdateSecretVersionStage,
+// retrying on InvalidRequestException/ResourceExistsException, which Secrets
+// Manager raises when a concurrent rotation has already moved the stage out
+// from under us. InvalidRequestException is also the code Secrets Manager
+// uses for the terminal "secret is scheduled for/already deleted" case, so
+// that message is excluded from the retry to avoid spinning for the full
+// timeout on an unrecoverable error.
+func updateSecretsManagerSecretVersionStageWithRetry(conn *secretsmanager.SecretsManager, input *secretsmanager.UpdateSecretVersionStageInput) error {
+	return resource.Retry(waiter.PropagationTimeout, func() *resource.RetryError {
+		log.Printf("[DEBUG] Updating Secrets Manager Secret Version Stage: %s", input)
+		_, err := conn.UpdateSecretVersionStage(input)
+
+		if tfawserr.ErrMessageContains(err, secretsmanager.ErrCodeInvalidRequestException, "You can’t perform this operation on the secret because it was deleted") {
+			return resource.NonRetryableError(err)
+		}
+
+		if tfawserr.ErrCodeEquals(err, secretsmanager.ErrCodeInvalidRequestException) || tfawserr.ErrCodeEquals(err, secretsmanager.ErrCodeResourceExistsException) {
+			return resource.RetryableError(err)
+		}
+
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		return nil
+	})
+}
+
+func sliceContainsString(list []*string, s string) bool {
+	for _, v := range list {
+		if aws.StringValue(v) == s {
+			return true
+		}
 	}
-	return idParts[0], idParts[1], nil
+	return false
 }